@@ -2,36 +2,59 @@ package application
 
 import (
 	"context"
-	"errors"
-	"github.com/Auvitly/application/internal/types"
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/Auvitly/application/internal/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // Application - implements the start of services and their completion.
 type Application struct {
-	// contains a list of registered constructors.
-	constructors []Constructor
-	// contains a list of started services.
-	services []Service
+	// contains a list of registered services, paired with their RestartPolicy.
+	entries []*serviceEntry
 	// contains a list of started resources.
-	resources []io.Closer
+	resources []*resourceEntry
+	// contains a list of named providers registered through Provide.
+	providers []*provider
 	// application launch configuration.
 	config *Config
 	// log for application.
 	logger Logger
+	// externally observable lifecycle state and readiness checks.
+	health *Health
+	// systemd sd_notify integration; a no-op on platforms other than Linux.
+	notify *notifySocket
 
 	// current application state.
 	state state
+	// signals that cause Run to cancel the service context and begin shutdown.
+	signals []os.Signal
+	// cancels the context passed to every supervised service's Serve; called by the graceful
+	// shutdown phase to ask services to stop cooperatively before the hammer phase force-closes
+	// them. Nil until Run starts supervising services.
+	cancelServe context.CancelFunc
+	// closed once every supervised service goroutine has returned. Starts pre-closed so that
+	// Shutdown called before Run (or without Run ever being called) doesn't block on it.
+	servedDone chan struct{}
+	// guards teardown so Shutdown is idempotent and safe to call from multiple goroutines.
+	shutdownOnce sync.Once
+	shutdownErr  error
+
 	// The channel defining initialization status.
 	initCh chan types.OperationResult
-	// The channel that determines the application's exit status.
-	shutdownCh chan types.OperationResult
 	// The channel that determines whether all services are running and the application has started.
 	runCh chan struct{}
+	// The channel used by Recover to report a recovered panic from a constructor; read by Init.
+	errCh chan error
+	// The channel used to notify subscribers that a panic was recovered.
+	panicCh chan PanicSignal
 }
 
 var defaultTerminateSyscall = []os.Signal{
@@ -41,16 +64,44 @@ var defaultTerminateSyscall = []os.Signal{
 	syscall.SIGQUIT,
 }
 
+// PanicSignal - sent on the instance's panic channel when Recover or a supervised service
+// recovers a panic. The send is dropped unless a receiver is already waiting.
 type PanicSignal struct{}
 
-// The channel was created to send a signal about the occurrence of a panic to subsequent methods for processing.
-var panicCh = make(chan PanicSignal)
+// ContextService - a Service that cooperates with cancellation instead of being force-Close()d.
+// ServeContext is named distinctly from Service.Serve (rather than overloading Serve with a ctx
+// parameter) because a concrete type's method set can only have one method named Serve; a
+// service that wants cooperative cancellation implements both Service and ContextService side by
+// side. When an Application runs a ContextService, ServeContext receives a context that is
+// cancelled as soon as any other supervised service fails, so implementations should return
+// promptly once ctx is done.
+type ContextService interface {
+	ServeContext(ctx context.Context) error
+}
 
-// The channel is created to negotiate application termination via system calls.
-var exitCh = make(chan os.Signal, 10)
+// serviceEntry pairs a registered Constructor with the RestartPolicy that governs how its
+// service is supervised, and tracks the currently built service and its status.
+type serviceEntry struct {
+	constructor Constructor
+	policy      RestartPolicy
+	service     Service
+	status      ServiceStatus
+	// level orders teardown: the hammer shutdown phase closes entries in descending level order,
+	// so a higher level always closes before a lower one. Services registered directly through
+	// RegistrationService/RegistrationServiceWithPolicy get a level equal to their registration
+	// index, giving them strict reverse-registration-order (LIFO) teardown. Services built by a
+	// Provide provider get a level offset above every such index, by the provider's depth in the
+	// dependency graph, so a provider is never closed before something built on top of it, while
+	// independent providers at the same depth still close concurrently.
+	level int
+}
 
-// A channel that allows you to intercept the error of one service.
-var errCh = make(chan error)
+// resourceEntry pairs a registered resource with the same teardown-ordering level serviceEntry
+// tracks, and for the same reason.
+type resourceEntry struct {
+	closer io.Closer
+	level  int
+}
 
 // New - creating an application instance.
 func New(config *Config) *Application {
@@ -58,13 +109,31 @@ func New(config *Config) *Application {
 		config:     config,
 		logger:     &emptyLogger{},
 		initCh:     make(chan types.OperationResult),
-		shutdownCh: make(chan types.OperationResult),
 		runCh:      make(chan struct{}),
+		errCh:      make(chan error),
+		panicCh:    make(chan PanicSignal),
+		health:     newHealth(),
+		notify:     newNotifySocket(),
+		servedDone: closedChan(),
 	}
 
 	return app
 }
 
+// RegisterHealthCheck adds a named liveness/readiness probe. check is invoked on every request
+// to the built-in /readyz and /healthz endpoints (see Config.HealthHTTPAddr) and must return
+// promptly.
+func (app *Application) RegisterHealthCheck(name string, check func(ctx context.Context) error) {
+	app.health.register(name, check)
+}
+
+// HealthEvents returns a channel of HealthEvent published each time the application's health
+// state is set, for programmatic subscribers such as metrics collectors or sidecars. The channel
+// buffers one event; an event is dropped if the previous one has not been read yet.
+func (app *Application) HealthEvents() <-chan HealthEvent {
+	return app.health.events
+}
+
 // SetLogger sets the logger for package output.
 func (app *Application) SetLogger(logger Logger) {
 	if logger != nil {
@@ -81,15 +150,41 @@ func (app *Application) log() Logger {
 }
 
 // RegistrationService - registering Constructor with internally initialized dependencies.
+// Services registered this way have no RestartPolicy: a Serve failure is fatal, same as before
+// RestartPolicy existed. Use RegistrationServiceWithPolicy to supervise restarts.
 func (app *Application) RegistrationService(constructors ...Constructor) (err error) {
+	return app.RegistrationServiceWithPolicy(RestartPolicy{}, constructors...)
+}
+
+// RegistrationServiceWithPolicy - registering Constructor under a RestartPolicy. When the built
+// service's Serve method returns an error (or panics), the supervisor backs off and re-invokes
+// the Constructor to rebuild the service, up to policy.MaxRetries times, before escalating to
+// the application's fatal path.
+func (app *Application) RegistrationServiceWithPolicy(policy RestartPolicy, constructors ...Constructor) (err error) {
 	if app.state != stateInit {
 		return ErrWrongState
 	}
-	app.constructors = append(app.constructors, constructors...)
+	for i := range constructors {
+		app.entries = append(app.entries, &serviceEntry{
+			constructor: constructors[i],
+			policy:      policy,
+			status:      ServiceStatusPending,
+			level:       len(app.entries),
+		})
+	}
 	app.log().Printf("Services registered %d", len(constructors))
 	return nil
 }
 
+// ServiceStatus reports the current supervision status of the service registered at index,
+// in registration order.
+func (app *Application) ServiceStatus(index int) (ServiceStatus, error) {
+	if index < 0 || index >= len(app.entries) {
+		return ServiceStatusPending, ErrServiceNotFound
+	}
+	return app.entries[index].status, nil
+}
+
 // RegistrationResource - registering resource Destructors.
 func (app *Application) RegistrationResource(resources ...io.Closer) (err error) {
 	if app.state != stateInit {
@@ -99,13 +194,13 @@ func (app *Application) RegistrationResource(resources ...io.Closer) (err error)
 	for i := range resources {
 		var isContain bool
 		for j := range app.resources {
-			if resources[i] == app.resources[j] {
+			if resources[i] == app.resources[j].closer {
 				isContain = true
 				break
 			}
 		}
 		if !isContain {
-			app.resources = append(app.resources, resources[i])
+			app.resources = append(app.resources, &resourceEntry{closer: resources[i], level: len(app.resources)})
 		}
 	}
 	app.log().Printf("Resources registered %d", len(resources))
@@ -119,6 +214,23 @@ func (app *Application) Init(ctx context.Context, signals ...os.Signal) (err err
 		return ErrWrongState
 	}
 
+	app.health.setState(HealthStarting)
+
+	if len(signals) == 0 {
+		app.signals = defaultTerminateSyscall
+	} else {
+		app.signals = signals
+	}
+
+	if addr := app.config.HealthHTTPAddr; addr != "" {
+		app.resources = append(app.resources, &resourceEntry{closer: app.health.startServer(addr, app.log()), level: len(app.resources)})
+	}
+
+	if err := app.resolveProvidersSafely(); err != nil {
+		app.health.setState(HealthUnhealthy)
+		return err
+	}
+
 	var (
 		initCtx       context.Context
 		initCtxCancel context.CancelFunc
@@ -130,7 +242,7 @@ func (app *Application) Init(ctx context.Context, signals ...os.Signal) (err err
 	}
 	defer initCtxCancel()
 
-	go app.init(ctx, signals...)
+	go app.init(ctx)
 
 	err = func() error {
 		for {
@@ -147,157 +259,179 @@ func (app *Application) Init(ctx context.Context, signals ...os.Signal) (err err
 				return ErrInitContextDeadline
 			case <-initCtx.Done():
 				return ErrInitTimeout
-			case <-exitCh:
+			case <-app.errCh:
 				return ErrInitConstructorPanic
 			}
 		}
 	}()
 	if err != nil {
+		app.health.setState(HealthUnhealthy)
 		return err
 	}
 	close(app.initCh)
 
 	app.state = stateReady
+	app.health.setState(HealthReady)
+	app.notify.ready()
 	app.log().Print("Application initialized")
 
 	return nil
 }
 
-func (app *Application) init(ctx context.Context, signals ...os.Signal) {
-	defer Recover()
+func (app *Application) init(ctx context.Context) {
+	defer app.Recover()
 
-	for i := range app.constructors {
-		var service Service
-		var err error
-		service, err = app.constructors[i](ctx, app)
+	for i := range app.entries {
+		// Entries built by resolveProviders already have a service and no constructor to
+		// replay.
+		if app.entries[i].service != nil {
+			continue
+		}
+
+		service, err := app.entries[i].constructor(ctx, app)
 		if err != nil {
 			app.initCh <- types.ResultError
+			return
 		}
-		app.services = append(app.services, service)
-	}
-
-	if len(signals) == 0 {
-		signal.Notify(exitCh, defaultTerminateSyscall...)
-	} else {
-		signal.Notify(exitCh, signals...)
+		app.entries[i].service = service
 	}
 
 	app.initCh <- types.ResultSuccess
 }
 
-// Run - launching the ready application.
+// Run - launching the ready application. Every registered service is supervised by a shared
+// errgroup.Group: the first service to return an error cancels the context passed to every
+// ContextService, and Run returns that first error once every supervised service has returned.
+// A Service that is not a ContextService does not observe that cancellation, so Run waits for
+// its Serve to return.
 func (app *Application) Run(ctx context.Context) (err error) {
 	if app.state != stateReady {
 		return ErrWrongState
 	}
 
-	go app.run()
-	defer func() {
-		app.Shutdown()
-	}()
+	runCtx, stop := signal.NotifyContext(ctx, app.signals...)
+	defer stop()
+
+	serveCtx, cancelServe := context.WithCancel(runCtx)
+	app.cancelServe = cancelServe
+	defer cancelServe()
+
+	go app.notify.watchdog(serveCtx)
+
+	group, groupCtx := errgroup.WithContext(serveCtx)
+
+	app.servedDone = make(chan struct{})
+	for i := range app.entries {
+		entry := app.entries[i]
+		group.Go(func() error {
+			return app.superviseEntry(groupCtx, entry)
+		})
+	}
 
 	app.state = stateRunning
 	app.log().Print("Application started")
 
-	for {
-		select {
-		case signal := <-exitCh:
-			if signal == types.SIGPANIC {
-				err = <-errCh
-				app.log().Printf("A panic was detected in the service with the message: %v", err)
-				if app.config.EnableDebugStack {
-					app.log().Printf("Debug stack info: %s", string(debug.Stack()))
-				}
-				return ErrRunPanic
-			}
-			return nil
-		case <-ctx.Done():
+	waitErr := group.Wait()
+	close(app.servedDone)
+
+	defer app.Shutdown()
+
+	if waitErr != nil {
+		if ctx.Err() != nil {
 			app.log().Printf("Service stopped due to context deadline")
 			return ErrRunContextDeadline
-		case err = <-errCh:
-			app.log().Printf("Service stopped due to context deadline")
-			return err
-		default:
 		}
+		app.log().Printf("A service reported a fatal error: %v", waitErr)
+		return waitErr
 	}
 
+	return nil
 }
 
-func (app *Application) run() {
-	// Start all services with error handling
-	for i := range app.services {
-		go func() {
-			defer Recover()
-			if err := app.services[i].Serve(); err != nil {
-				errCh <- err
+// serveEntry runs entry's current service once, recovering a panic into an error rather than
+// crashing the process, so that superviseEntry can treat it the same as a returned error.
+func (app *Application) serveEntry(ctx context.Context, entry *serviceEntry) (err error) {
+	defer func() {
+		if msg := recover(); msg != nil {
+			if app.config.EnableDebugStack {
+				app.log().Printf("Debug stack info: %s", string(debug.Stack()))
+			}
+			select {
+			case app.panicCh <- PanicSignal{}:
+			default:
 			}
-		}()
+			err = fmt.Errorf("%w: %v", ErrRunPanic, msg)
+		}
+	}()
+
+	if contextService, ok := entry.service.(ContextService); ok {
+		return contextService.ServeContext(ctx)
 	}
+	return entry.service.Serve()
 }
 
-// Shutdown - shutdown the application.
-func (app *Application) Shutdown() (err error) {
-	app.state = stateShutdown
+// superviseEntry runs entry until it stops cleanly, ctx is cancelled, or its RestartPolicy is
+// exhausted, rebuilding the service from its Constructor between restart attempts. The backoff
+// sleep is interruptible by ctx so shutdown is never blocked behind a pending restart.
+func (app *Application) superviseEntry(ctx context.Context, entry *serviceEntry) error {
+	var attempt int
+	entry.status = ServiceStatusRunning
 
-	var (
-		shutdownCtx    context.Context
-		shutdownCancel context.CancelFunc
-	)
-	if app.config.InitialisationTimeout != 0 {
-		shutdownCtx, shutdownCancel = context.WithTimeout(context.Background(), app.config.TerminationTimeout)
-	} else {
-		shutdownCtx, shutdownCancel = context.WithCancel(context.Background())
-	}
-	defer shutdownCancel()
-
-	go app.shutdown()
+	for {
+		err := app.serveEntry(ctx, entry)
+		if err == nil || ctx.Err() != nil {
+			entry.status = ServiceStatusStopped
+			return nil
+		}
 
-	err = func() error {
-		for {
-			select {
-			case <-app.shutdownCh:
-				app.log().Printf("Safe termination completed successfully")
-				return nil
-			case <-shutdownCtx.Done():
-				app.log().Printf("Graceful shutdown of the application was aborted due termination timeout")
-				return ErrTerminateTimeout
+		attempt++
+		if !entry.policy.shouldRestart(attempt, err) {
+			entry.status = ServiceStatusFatal
+			if entry.policy.MaxRetries > 0 && attempt > entry.policy.MaxRetries {
+				return fmt.Errorf("%w: %v", ErrMaxRetriesExceeded, err)
 			}
+			return err
 		}
-	}()
 
-	app.state = stateOff
-	return err
-}
+		entry.status = ServiceStatusBackoff
+		backoff := entry.policy.backoff(attempt)
+		app.log().Printf("Service failed with %v, restarting in %s (attempt %d/%d)", err, backoff, attempt, entry.policy.MaxRetries)
 
-func (app *Application) shutdown() {
-	for i := range app.services {
-		err := app.services[i].Close()
-		if err != nil {
-			app.log().Printf("Service shutdown error: %v", err)
-			continue
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			entry.status = ServiceStatusStopped
+			return nil
 		}
-	}
-	for i := range app.resources {
-		err := app.resources[i].Close()
-		if err != nil {
-			app.log().Printf("Resource shutdown error: %v", err)
-			continue
+
+		service, buildErr := entry.constructor(ctx, app)
+		if buildErr != nil {
+			entry.status = ServiceStatusFatal
+			return buildErr
 		}
+		entry.service = service
+		entry.status = ServiceStatusRunning
 	}
-	app.shutdownCh <- types.ResultSuccess
 }
 
-// Recover - global method for catching application panics.
-func Recover() {
+// Recover - recovers a panic in the constructor goroutine started by Init and reports it on the
+// instance's error channel, which only Init reads, instead of re-panicking; a panic value of any
+// type is reported. Panics in running services are recovered by serveEntry, not here.
+func (app *Application) Recover() {
 	if panicMsg := recover(); panicMsg != nil {
-		exitCh <- types.SIGPANIC
-		errCh <- errors.New(panicMsg.(string))
-		panicCh <- PanicSignal{}
+		if app.config.EnableDebugStack {
+			app.log().Printf("Debug stack info: %s", string(debug.Stack()))
+		}
+		select {
+		case app.panicCh <- PanicSignal{}:
+		default:
+		}
+		app.errCh <- fmt.Errorf("%w: %v", ErrRunPanic, panicMsg)
 	}
 }
 
 // Panic - the method returns a channel for reading to process the panic state in the methods
 // for collecting metrics, checking health, etc.
-func Panic() <-chan PanicSignal {
-	return panicCh
+func (app *Application) Panic() <-chan PanicSignal {
+	return app.panicCh
 }
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingService is a ContextService that only returns once its context is cancelled, the
+// common shape of a real network service (e.g. an HTTP server blocking in Serve until asked to
+// stop). It exists to prove Run actually observes cancellation through ContextService instead of
+// hanging forever behind a Serve() that takes no context.
+type blockingService struct{}
+
+func (blockingService) Serve() error { return nil }
+func (blockingService) Close() error { return nil }
+func (blockingService) ServeContext(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestRunReturnsAfterContextCancellation(t *testing.T) {
+	app := New(&Config{})
+	if err := app.RegistrationService(func(ctx context.Context, _ *Application) (Service, error) {
+		return blockingService{}, nil
+	}); err != nil {
+		t.Fatalf("RegistrationService() error = %v", err)
+	}
+	if err := app.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run(runCtx) }()
+
+	// Give Run a moment to start supervising before asking it to stop.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run() error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after context cancellation; ServeContext was never dispatched")
+	}
+}
+
+// orderedService records the position in which it is closed, so tests can assert on teardown
+// order without depending on timing.
+type orderedService struct {
+	index      int
+	mu         *sync.Mutex
+	closeOrder *[]int
+}
+
+func (s orderedService) Serve() error { return nil }
+func (s orderedService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.closeOrder = append(*s.closeOrder, s.index)
+	return nil
+}
+
+func TestShutdownClosesServicesInReverseRegistrationOrder(t *testing.T) {
+	app := New(&Config{})
+
+	var (
+		mu         sync.Mutex
+		closeOrder []int
+	)
+	for i := 0; i < 3; i++ {
+		i := i
+		err := app.RegistrationService(func(ctx context.Context, _ *Application) (Service, error) {
+			return orderedService{index: i, mu: &mu, closeOrder: &closeOrder}, nil
+		})
+		if err != nil {
+			t.Fatalf("RegistrationService() error = %v", err)
+		}
+	}
+
+	if err := app.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := app.Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := []int{2, 1, 0}
+	if len(closeOrder) != len(want) {
+		t.Fatalf("closeOrder = %v, want %v", closeOrder, want)
+	}
+	for i := range want {
+		if closeOrder[i] != want[i] {
+			t.Fatalf("closeOrder = %v, want %v (reverse registration order)", closeOrder, want)
+		}
+	}
+}
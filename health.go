@@ -0,0 +1,150 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// HealthState describes the externally observable lifecycle phase of an Application, mirroring
+// its internal state machine but safe to read from outside the package.
+type HealthState int
+
+const (
+	// HealthStarting is set as soon as Init begins.
+	HealthStarting HealthState = iota
+	// HealthReady is set once Init has completed successfully.
+	HealthReady
+	// HealthShuttingDown is set at the start of Shutdown, before any Close call.
+	HealthShuttingDown
+	// HealthUnhealthy is set when Init fails.
+	HealthUnhealthy
+)
+
+func (state HealthState) String() string {
+	switch state {
+	case HealthStarting:
+		return "starting"
+	case HealthReady:
+		return "ready"
+	case HealthShuttingDown:
+		return "shutting_down"
+	case HealthUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthEvent is published on the channel returned by Application.HealthEvents whenever the
+// application's HealthState changes.
+type HealthEvent struct {
+	State HealthState
+}
+
+// healthCheck pairs a named liveness/readiness probe with its check function.
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// Health tracks the application's externally observable lifecycle state and the set of
+// registered health checks that gate readiness.
+type Health struct {
+	mu     sync.RWMutex
+	state  HealthState
+	checks []healthCheck
+	events chan HealthEvent
+
+	server *http.Server
+}
+
+func newHealth() *Health {
+	return &Health{
+		state:  HealthStarting,
+		events: make(chan HealthEvent, 1),
+	}
+}
+
+func (health *Health) setState(state HealthState) {
+	health.mu.Lock()
+	health.state = state
+	health.mu.Unlock()
+
+	select {
+	case health.events <- HealthEvent{State: state}:
+	default:
+	}
+}
+
+func (health *Health) register(name string, check func(ctx context.Context) error) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	health.checks = append(health.checks, healthCheck{name: name, check: check})
+}
+
+// snapshot reports the current state and runs every registered check against ctx, returning one
+// error per failing check.
+func (health *Health) snapshot(ctx context.Context) (HealthState, []error) {
+	health.mu.RLock()
+	state := health.state
+	checks := append([]healthCheck(nil), health.checks...)
+	health.mu.RUnlock()
+
+	var failures []error
+	for i := range checks {
+		if err := checks[i].check(ctx); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", checks[i].name, err))
+		}
+	}
+	return state, failures
+}
+
+// handle serves /livez, /readyz and /healthz alike: 200 only while the application is Ready and
+// every registered check passes, 503 otherwise.
+func (health *Health) handle(w http.ResponseWriter, r *http.Request) {
+	state, failures := health.snapshot(r.Context())
+
+	status := http.StatusOK
+	if state != HealthReady || len(failures) != 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	errs := make([]string, len(failures))
+	for i := range failures {
+		errs[i] = failures[i].Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		State  string   `json:"state"`
+		Errors []string `json:"errors,omitempty"`
+	}{
+		State:  state.String(),
+		Errors: errs,
+	})
+}
+
+// startServer starts the opt-in health HTTP server on addr and returns it so the caller can
+// register it for teardown. Listener failures are logged rather than propagated: a broken
+// health endpoint should not take the rest of the application down.
+func (health *Health) startServer(addr string, logger Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", health.handle)
+	mux.HandleFunc("/readyz", health.handle)
+	mux.HandleFunc("/healthz", health.handle)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Printf("Health HTTP server stopped: %v", err)
+		}
+	}()
+
+	health.server = server
+	return server
+}
@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthSetStatePublishesEvents(t *testing.T) {
+	health := newHealth()
+
+	health.setState(HealthReady)
+	select {
+	case event := <-health.events:
+		if event.State != HealthReady {
+			t.Fatalf("event.State = %v, want HealthReady", event.State)
+		}
+	default:
+		t.Fatal("setState did not publish an event")
+	}
+
+	state, _ := health.snapshot(context.Background())
+	if state != HealthReady {
+		t.Fatalf("snapshot state = %v, want HealthReady", state)
+	}
+}
+
+func TestHealthSnapshotReportsFailingChecks(t *testing.T) {
+	health := newHealth()
+	health.setState(HealthReady)
+
+	boom := errors.New("boom")
+	health.register("ok", func(ctx context.Context) error { return nil })
+	health.register("broken", func(ctx context.Context) error { return boom })
+
+	state, failures := health.snapshot(context.Background())
+	if state != HealthReady {
+		t.Fatalf("snapshot state = %v, want HealthReady", state)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("len(failures) = %d, want 1", len(failures))
+	}
+}
+
+func TestHealthHandleReportsUnavailableUntilReady(t *testing.T) {
+	health := newHealth()
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	rec := httptest.NewRecorder()
+	health.handle(rec, req)
+	if rec.Code != 503 {
+		t.Fatalf("handle() before Ready = %d, want 503", rec.Code)
+	}
+
+	health.setState(HealthReady)
+	rec = httptest.NewRecorder()
+	health.handle(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("handle() once Ready = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package application
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notifySocket wraps the systemd sd_notify protocol: a best-effort datagram send to the socket
+// named by $NOTIFY_SOCKET. Every method is a no-op when NOTIFY_SOCKET is unset, so an
+// application not running under systemd pays no cost.
+type notifySocket struct {
+	addr *net.UnixAddr
+}
+
+func newNotifySocket() *notifySocket {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return &notifySocket{}
+	}
+	return &notifySocket{addr: &net.UnixAddr{Name: path, Net: "unixgram"}}
+}
+
+func (notify *notifySocket) send(state string) {
+	if notify == nil || notify.addr == nil {
+		return
+	}
+	conn, err := net.DialUnix("unixgram", nil, notify.addr)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte(state))
+}
+
+// ready sends READY=1, signalling that Init has completed successfully.
+func (notify *notifySocket) ready() { notify.send("READY=1") }
+
+// stopping sends STOPPING=1, signalling the start of Shutdown.
+func (notify *notifySocket) stopping() { notify.send("STOPPING=1") }
+
+// watchdogInterval parses $WATCHDOG_USEC and returns half that interval, or zero if the
+// watchdog is not enabled.
+func watchdogInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("WATCHDOG_USEC"))
+	if raw == "" {
+		return 0
+	}
+	usec, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// watchdog pings the systemd watchdog at half its advertised interval until ctx is done. It
+// returns immediately if NOTIFY_SOCKET or WATCHDOG_USEC is unset.
+func (notify *notifySocket) watchdog(ctx context.Context) {
+	interval := watchdogInterval()
+	if notify == nil || notify.addr == nil || interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			notify.send("WATCHDOG=1")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
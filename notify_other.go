@@ -0,0 +1,16 @@
+//go:build !linux
+
+package application
+
+import "context"
+
+// notifySocket is a no-op stand-in for the systemd sd_notify integration on non-Linux platforms.
+type notifySocket struct{}
+
+func newNotifySocket() *notifySocket { return &notifySocket{} }
+
+func (notify *notifySocket) ready() {}
+
+func (notify *notifySocket) stopping() {}
+
+func (notify *notifySocket) watchdog(ctx context.Context) {}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ErrProviderSignature is returned by Provide when ctor is not a function shaped
+// func(Dep1, Dep2, ...) (Value, error) or func(Dep1, Dep2, ...) Value, with one parameter per
+// entry in deps.
+var ErrProviderSignature = errors.New("application: provider has an unresolvable signature")
+
+// ErrProviderNotFound is returned during Init when a provider declares a dependency name that
+// was never registered through Provide.
+var ErrProviderNotFound = errors.New("application: provider dependency not found")
+
+// ErrProviderCycle is returned during Init when the dependency graph built by Provide contains a
+// cycle.
+var ErrProviderCycle = errors.New("application: provider dependency cycle detected")
+
+var errType = reflect.TypeOf((*error)(nil)).Elem()
+
+// provider is a named constructor registered through Application.Provide.
+type provider struct {
+	name string
+	ctor reflect.Value
+	deps []string
+}
+
+// Provide registers a named provider in Application's dependency-ordered container. ctor is a
+// function whose parameters are resolved, by position, from the values previously provided under
+// the names listed in deps; it must return either a single value, or a value and an error.
+//
+// During Init, providers are topologically sorted by deps, constructed in dependency order, and
+// any provided value implementing Service is supervised like one registered through
+// RegistrationService, while a value implementing io.Closer (and not Service) is automatically
+// registered as a resource for reverse-order teardown, making RegistrationResource unnecessary
+// for most providers.
+func (app *Application) Provide(name string, ctor any, deps ...string) error {
+	if app.state != stateInit {
+		return ErrWrongState
+	}
+
+	ctorValue := reflect.ValueOf(ctor)
+	if ctorValue.Kind() != reflect.Func {
+		return fmt.Errorf("%w: %s: ctor must be a function", ErrProviderSignature, name)
+	}
+	ctorType := ctorValue.Type()
+	if ctorType.NumIn() != len(deps) {
+		return fmt.Errorf("%w: %s: ctor takes %d parameters, got %d deps", ErrProviderSignature, name, ctorType.NumIn(), len(deps))
+	}
+	switch ctorType.NumOut() {
+	case 1:
+	case 2:
+		if ctorType.Out(1) != errType {
+			return fmt.Errorf("%w: %s: second return value must be error", ErrProviderSignature, name)
+		}
+	default:
+		return fmt.Errorf("%w: %s: ctor must return (value) or (value, error)", ErrProviderSignature, name)
+	}
+
+	app.providers = append(app.providers, &provider{name: name, ctor: ctorValue, deps: deps})
+	app.log().Printf("Provider registered %q", name)
+	return nil
+}
+
+// resolveProvidersSafely runs resolveProviders, recovering a panic from a misbehaving provider
+// into an error rather than crashing Init.
+func (app *Application) resolveProvidersSafely() (err error) {
+	defer func() {
+		if msg := recover(); msg != nil {
+			err = fmt.Errorf("%w: %v", ErrRunPanic, msg)
+		}
+	}()
+	return app.resolveProviders()
+}
+
+// resolveProviders topologically sorts the registered providers, constructs them in dependency
+// order, and registers the values they return for supervision or teardown.
+func (app *Application) resolveProviders() error {
+	order, err := topologicalSort(app.providers)
+	if err != nil {
+		return err
+	}
+
+	// Offset every provider's level above whatever plain RegistrationService/RegistrationResource
+	// entries are already registered, so every provider-built value is closed before those
+	// pre-existing registrations; providers that depend on each other still nest correctly
+	// above that floor, one level per step of dependency depth.
+	baseLevel := len(app.entries)
+	if len(app.resources) > baseLevel {
+		baseLevel = len(app.resources)
+	}
+
+	values := make(map[string]reflect.Value, len(order))
+	depths := make(map[string]int, len(order))
+	for _, p := range order {
+		args := make([]reflect.Value, len(p.deps))
+		var depth int
+		for i, dep := range p.deps {
+			value, ok := values[dep]
+			if !ok {
+				return fmt.Errorf("%w: %s depends on %q", ErrProviderNotFound, p.name, dep)
+			}
+			args[i] = value
+			if depDepth := depths[dep] + 1; depDepth > depth {
+				depth = depDepth
+			}
+		}
+
+		results := p.ctor.Call(args)
+		if len(results) == 2 && !results[1].IsNil() {
+			return fmt.Errorf("application: provider %q failed: %w", p.name, results[1].Interface().(error))
+		}
+
+		values[p.name] = results[0]
+		depths[p.name] = depth
+		app.registerProvided(results[0].Interface(), baseLevel+depth)
+	}
+
+	return nil
+}
+
+// registerProvided supervises value as a Service, registers it as a resource if it is merely an
+// io.Closer, or leaves it as a plain dependency otherwise. level is resolveProviders' base level
+// plus value's depth in the dependency graph, and is threaded through so the hammer shutdown
+// phase can close providers in dependency order instead of all at once.
+func (app *Application) registerProvided(value any, level int) {
+	if service, ok := value.(Service); ok {
+		app.entries = append(app.entries, &serviceEntry{service: service, status: ServiceStatusPending, level: level})
+		return
+	}
+	if closer, ok := value.(io.Closer); ok {
+		app.resources = append(app.resources, &resourceEntry{closer: closer, level: level})
+	}
+}
+
+// topologicalSort orders providers so that every dependency appears before its dependents,
+// detecting cycles along the way. Dependency names that don't match a registered provider are
+// left for resolveProviders to report as ErrProviderNotFound.
+func topologicalSort(providers []*provider) ([]*provider, error) {
+	byName := make(map[string]*provider, len(providers))
+	for _, p := range providers {
+		byName[p.name] = p
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	mark := make(map[string]int, len(providers))
+	order := make([]*provider, 0, len(providers))
+
+	var visit func(p *provider) error
+	visit = func(p *provider) error {
+		switch mark[p.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrProviderCycle, p.name)
+		}
+
+		mark[p.name] = visiting
+		for _, dep := range p.deps {
+			if depProvider, ok := byName[dep]; ok {
+				if err := visit(depProvider); err != nil {
+					return err
+				}
+			}
+		}
+		mark[p.name] = visited
+		order = append(order, p)
+		return nil
+	}
+
+	for _, p := range providers {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,59 @@
+package application
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTopologicalSortOrdersDependenciesFirst(t *testing.T) {
+	a := &provider{name: "a"}
+	b := &provider{name: "b", deps: []string{"a"}}
+	c := &provider{name: "c", deps: []string{"a", "b"}}
+
+	order, err := topologicalSort([]*provider{c, b, a})
+	if err != nil {
+		t.Fatalf("topologicalSort returned error: %v", err)
+	}
+
+	index := make(map[string]int, len(order))
+	for i, p := range order {
+		index[p.name] = i
+	}
+	if index["a"] > index["b"] {
+		t.Fatalf("a must come before b, got order %v", providerNames(order))
+	}
+	if index["b"] > index["c"] {
+		t.Fatalf("b must come before c, got order %v", providerNames(order))
+	}
+}
+
+func TestTopologicalSortDetectsCycle(t *testing.T) {
+	a := &provider{name: "a", deps: []string{"b"}}
+	b := &provider{name: "b", deps: []string{"a"}}
+
+	if _, err := topologicalSort([]*provider{a, b}); !errors.Is(err, ErrProviderCycle) {
+		t.Fatalf("topologicalSort() error = %v, want ErrProviderCycle", err)
+	}
+}
+
+func TestTopologicalSortIgnoresUnknownDependencies(t *testing.T) {
+	// A dep with no matching provider is left for resolveProviders to report as
+	// ErrProviderNotFound; topologicalSort itself must not error or drop the provider.
+	a := &provider{name: "a", deps: []string{"missing"}}
+
+	order, err := topologicalSort([]*provider{a})
+	if err != nil {
+		t.Fatalf("topologicalSort returned error: %v", err)
+	}
+	if len(order) != 1 || order[0].name != "a" {
+		t.Fatalf("topologicalSort() = %v, want [a]", providerNames(order))
+	}
+}
+
+func providerNames(providers []*provider) []string {
+	out := make([]string, len(providers))
+	for i, p := range providers {
+		out[i] = p.name
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrMaxRetriesExceeded is returned when a supervised service has exhausted its RestartPolicy
+// and the failure escalates to the application's fatal path.
+var ErrMaxRetriesExceeded = errors.New("application: service exceeded its restart policy")
+
+// ErrServiceNotFound is returned by Application.ServiceStatus when asked about an index outside
+// the range of registered services.
+var ErrServiceNotFound = errors.New("application: service not found")
+
+// RestartPolicy describes how a supervised service is rebuilt and restarted when its Serve
+// method returns an error. It mirrors classic process-supervisor semantics: a bounded number of
+// retries, each separated by an exponentially increasing, jittered backoff.
+type RestartPolicy struct {
+	// MaxRetries is the number of times the service may be rebuilt and restarted after a
+	// failure. Zero disables restart supervision: a failure is treated as fatal, same as a
+	// service registered through RegistrationService.
+	MaxRetries int
+	// InitialBackoff is the delay before the first restart attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponentially increasing delay between restart attempts.
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, of random variance applied to each backoff delay.
+	Jitter float64
+	// RestartOn decides whether a given Serve error should be restarted. A nil RestartOn
+	// restarts on every non-nil error.
+	RestartOn func(error) bool
+}
+
+// shouldRestart reports whether err warrants restart attempt number attempt (1-indexed) under
+// the policy, so at most MaxRetries restarts are allowed.
+func (policy RestartPolicy) shouldRestart(attempt int, err error) bool {
+	if policy.MaxRetries == 0 || attempt > policy.MaxRetries {
+		return false
+	}
+	if policy.RestartOn != nil {
+		return policy.RestartOn(err)
+	}
+	return true
+}
+
+// backoff computes the delay before restart attempt n (1-indexed), capped at MaxBackoff and
+// perturbed by Jitter.
+func (policy RestartPolicy) backoff(attempt int) time.Duration {
+	base := policy.InitialBackoff
+	if base <= 0 {
+		base = time.Second
+	}
+
+	shift := attempt - 1
+	if shift > 32 {
+		shift = 32
+	}
+	delay := base << shift
+
+	if policy.Jitter > 0 {
+		delay += time.Duration(rand.Float64() * policy.Jitter * float64(delay))
+	}
+	// Re-clamp after jitter: jitter only ever adds delay, so without this a jittered value could
+	// exceed MaxBackoff, the one bound RestartPolicy promises callers.
+	if max := policy.MaxBackoff; max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// ServiceStatus describes the supervision state of a registered service.
+type ServiceStatus int
+
+const (
+	// ServiceStatusPending is the status of a service that has not been started yet.
+	ServiceStatusPending ServiceStatus = iota
+	// ServiceStatusRunning is the status of a service whose Serve method is currently executing.
+	ServiceStatusRunning
+	// ServiceStatusBackoff is the status of a service waiting out its RestartPolicy backoff
+	// before being rebuilt and restarted.
+	ServiceStatusBackoff
+	// ServiceStatusStopped is the status of a service that returned without error, or that was
+	// asked to stop via context cancellation.
+	ServiceStatusStopped
+	// ServiceStatusFatal is the status of a service that exhausted its RestartPolicy, that has
+	// no policy and returned an error, whose RestartOn declined the error, or whose Constructor
+	// failed while rebuilding it for a restart.
+	ServiceStatusFatal
+)
+
+func (status ServiceStatus) String() string {
+	switch status {
+	case ServiceStatusPending:
+		return "pending"
+	case ServiceStatusRunning:
+		return "running"
+	case ServiceStatusBackoff:
+		return "backoff"
+	case ServiceStatusStopped:
+		return "stopped"
+	case ServiceStatusFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		policy  RestartPolicy
+		attempt int
+		err     error
+		want    bool
+	}{
+		{
+			name:    "zero policy never restarts",
+			policy:  RestartPolicy{},
+			attempt: 1,
+			err:     boom,
+			want:    false,
+		},
+		{
+			name:    "within budget restarts",
+			policy:  RestartPolicy{MaxRetries: 3},
+			attempt: 1,
+			err:     boom,
+			want:    true,
+		},
+		{
+			name:    "at budget still restarts",
+			policy:  RestartPolicy{MaxRetries: 3},
+			attempt: 3,
+			err:     boom,
+			want:    true,
+		},
+		{
+			name:    "beyond budget stops",
+			policy:  RestartPolicy{MaxRetries: 3},
+			attempt: 4,
+			err:     boom,
+			want:    false,
+		},
+		{
+			name:    "RestartOn vetoes",
+			policy:  RestartPolicy{MaxRetries: 3, RestartOn: func(error) bool { return false }},
+			attempt: 1,
+			err:     boom,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.shouldRestart(tt.attempt, tt.err); got != tt.want {
+				t.Fatalf("shouldRestart(%d, %v) = %v, want %v", tt.attempt, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestartPolicyBackoffRespectsMaxBackoff(t *testing.T) {
+	policy := RestartPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.5,
+	}
+
+	// A wide attempt range exercises both the pre-cap exponential growth and the case where
+	// jitter alone would push the delay past MaxBackoff if applied after the clamp.
+	for attempt := 1; attempt <= 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			if delay := policy.backoff(attempt); delay > policy.MaxBackoff {
+				t.Fatalf("backoff(%d) = %s, want <= MaxBackoff %s", attempt, delay, policy.MaxBackoff)
+			}
+		}
+	}
+}
+
+func TestRestartPolicyBackoffDefaultsInitialBackoff(t *testing.T) {
+	policy := RestartPolicy{MaxBackoff: time.Minute}
+	if delay := policy.backoff(1); delay != time.Second {
+		t.Fatalf("backoff(1) = %s, want the default InitialBackoff of %s", delay, time.Second)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package application
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrServiceUnsupported is returned by Bootstrap when Config requests a Windows Service action
+// on a non-Windows platform.
+var ErrServiceUnsupported = errors.New("application: Windows Service integration is not available on this platform")
+
+// Bootstrap inspects Config for the --register-service, --unregister-service and --run-service
+// flags. On non-Windows platforms only --run-service is meaningful, and it simply calls app.Run;
+// the other two report ErrServiceUnsupported.
+func Bootstrap(name string, app *Application) (handled bool, err error) {
+	switch {
+	case app.config.RegisterService, app.config.UnregisterService:
+		return true, ErrServiceUnsupported
+	case app.config.RunService:
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		return true, app.Run(ctx)
+	default:
+		return false, nil
+	}
+}
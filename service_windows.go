@@ -0,0 +1,185 @@
+//go:build windows
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// panicLogMaxBytes is the size at which panic.log is rotated to panic.log.1.
+const panicLogMaxBytes = 10 << 20 // 10 MiB
+
+// serviceExitCodeFatal is reported to the Service Control Manager when app.Run returns an error
+// that wasn't the result of an operator Stop/Shutdown request.
+const serviceExitCodeFatal uint32 = 1
+
+// windowsService adapts an Application to the svc.Handler interface expected by
+// golang.org/x/sys/windows/svc.
+type windowsService struct {
+	name string
+	app  *Application
+}
+
+// RunAsService hosts app as a Windows Service named name, blocking until the service manager
+// stops it. Stop and Shutdown control requests are translated into the existing Shutdown path,
+// and svc.StartPending/Running/StopPending are reported as the service moves through Application's
+// internal state machine. A panic in app.Run is written to the Windows Event Log and to a
+// rotating panic.log next to Config.PIDFile instead of crashing the process.
+func RunAsService(name string, app *Application) error {
+	return svc.Run(name, &windowsService{name: name, app: app})
+}
+
+// RegisterService installs exePath as a Windows Service named name, matching the
+// --register-service CLI convention.
+func RegisterService(name, exePath string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	if existing, err := manager.OpenService(name); err == nil {
+		existing.Close()
+		return fmt.Errorf("application: service %q is already registered", name)
+	}
+
+	service, err := manager.CreateService(name, exePath, mgr.Config{StartType: mgr.StartAutomatic})
+	if err != nil {
+		return err
+	}
+	return service.Close()
+}
+
+// UnregisterService removes a Windows Service previously installed by RegisterService, matching
+// the --unregister-service CLI convention.
+func UnregisterService(name string) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	return service.Delete()
+}
+
+// Bootstrap inspects Config for the --register-service, --unregister-service and --run-service
+// flags and performs the matching Windows Service action, reporting handled as true if one of
+// them applied. Callers should exit without calling app.Run when handled is true.
+func Bootstrap(name string, app *Application) (handled bool, err error) {
+	switch {
+	case app.config.RegisterService:
+		exe, err := os.Executable()
+		if err != nil {
+			return true, err
+		}
+		return true, RegisterService(name, exe)
+	case app.config.UnregisterService:
+		return true, UnregisterService(name)
+	case app.config.RunService:
+		return true, RunAsService(name, app)
+	default:
+		return false, nil
+	}
+}
+
+// Execute implements svc.Handler.
+func (s *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	changes <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() {
+		defer s.recoverToEventLog()
+		runErr <- s.app.Run(ctx)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: accepted}
+
+	for {
+		select {
+		case err := <-runErr:
+			changes <- svc.Status{State: svc.StopPending}
+			if err != nil {
+				s.logEvent(true, fmt.Sprintf("application stopped with error: %v", err))
+				// A nonzero, non-operator-requested exit tells the Service Control Manager the
+				// process failed, so its recovery actions (restart, reboot, run command) fire.
+				return true, serviceExitCodeFatal
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+				s.app.Shutdown()
+				return false, 0
+			}
+		}
+	}
+}
+
+func (s *windowsService) logEvent(isError bool, msg string) {
+	log, err := eventlog.Open(s.name)
+	if err != nil {
+		return
+	}
+	defer log.Close()
+
+	if isError {
+		_ = log.Error(1, msg)
+	} else {
+		_ = log.Info(1, msg)
+	}
+}
+
+// recoverToEventLog turns a panic in app.Run into an Event Log entry and a panic.log line
+// instead of crashing the service process.
+func (s *windowsService) recoverToEventLog() {
+	if msg := recover(); msg != nil {
+		s.logEvent(true, fmt.Sprintf("panic: %v", msg))
+		s.writePanicFile(msg)
+	}
+}
+
+func (s *windowsService) panicLogPath() string {
+	dir := "."
+	if pidFile := s.app.config.PIDFile; pidFile != "" {
+		dir = filepath.Dir(pidFile)
+	}
+	return filepath.Join(dir, "panic.log")
+}
+
+func (s *windowsService) writePanicFile(msg any) {
+	path := s.panicLogPath()
+	if info, err := os.Stat(path); err == nil && info.Size() > panicLogMaxBytes {
+		_ = os.Rename(path, path+".1")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "%s panic: %v\n%s\n", time.Now().Format(time.RFC3339), msg, debug.Stack())
+}
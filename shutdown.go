@@ -0,0 +1,194 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// ErrHammerTimeout is returned by Shutdown when Config.HammerTimeout (or TerminationTimeout)
+// elapses before every service and resource finishes closing. Goroutines still blocked at that
+// point (typically the legacy, non-context Close() error fallback) are abandoned in the
+// background; their eventual results are discarded.
+var ErrHammerTimeout = errors.New("application: hammer shutdown phase timed out")
+
+// HammerCloser is implemented by services whose Close accepts a context. CloseContext is named
+// distinctly from Service.Close (rather than overloading Close with a ctx parameter) because a
+// concrete type's method set can only have one method named Close. During the hammer phase of
+// Shutdown, CloseContext is called with a context bounded by Config.HammerTimeout (or
+// TerminationTimeout), so implementations can distinguish "please wrap up" (GracefulTimeout, via
+// ContextService.ServeContext) from "stop now, you have until ctx is done" (HammerTimeout).
+// Services that only implement Service fall back to the legacy Close() error, which this package
+// cannot interrupt once called.
+type HammerCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+func closedChan() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// Shutdown - shutdown the application. It is idempotent and safe to call concurrently: only the
+// first call performs teardown, and every caller observes the same result.
+func (app *Application) Shutdown() error {
+	app.shutdownOnce.Do(func() {
+		app.shutdownErr = app.teardown()
+	})
+	return app.shutdownErr
+}
+
+// teardown runs the two-phase shutdown: a graceful phase that cancels the context passed to
+// Serve and waits (up to Config.GracefulTimeout) for services to stop cooperatively, followed by
+// a hammer phase (bounded by Config.HammerTimeout) that force-closes services and resources in
+// descending dependency-level order, concurrently within each level. A zero GracefulTimeout or
+// HammerTimeout falls back to TerminationTimeout; if that is zero too, the phase waits
+// indefinitely instead of being skipped, matching the convention Init uses for
+// InitialisationTimeout.
+func (app *Application) teardown() error {
+	app.state = stateShutdown
+	app.health.setState(HealthShuttingDown)
+	app.notify.stopping()
+
+	graceful := app.config.GracefulTimeout
+	if graceful <= 0 {
+		graceful = app.config.TerminationTimeout
+	}
+	gracefulCtx, gracefulCancel := contextWithOptionalTimeout(graceful)
+	defer gracefulCancel()
+
+	if app.cancelServe != nil {
+		app.cancelServe()
+	}
+	select {
+	case <-app.servedDone:
+	case <-gracefulCtx.Done():
+		app.log().Printf("Graceful shutdown phase timed out, escalating to forced termination")
+	}
+
+	hammer := app.config.HammerTimeout
+	if hammer <= 0 {
+		hammer = app.config.TerminationTimeout
+	}
+	hammerCtx, hammerCancel := contextWithOptionalTimeout(hammer)
+	defer hammerCancel()
+
+	err := multierr.Append(app.closeServices(hammerCtx), app.closeResources(hammerCtx))
+	if err != nil {
+		app.log().Printf("Shutdown completed with errors: %v", err)
+	} else {
+		app.log().Printf("Safe termination completed successfully")
+	}
+
+	app.state = stateOff
+	return err
+}
+
+// contextWithOptionalTimeout returns a context bounded by timeout, or one with no deadline at
+// all (cancelled only by the returned CancelFunc) when timeout is zero, so a zero-value
+// GracefulTimeout or HammerTimeout means "wait as long as it takes" rather than "don't wait".
+func contextWithOptionalTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// closeServices force-closes every built service, in descending dependency-level order,
+// concurrently within each level, so a provider is never closed before something built on top of
+// it.
+func (app *Application) closeServices(ctx context.Context) error {
+	levels := make([]int, len(app.entries))
+	for i, entry := range app.entries {
+		levels[i] = entry.level
+	}
+	return closeByLevel(ctx, levels, func(i int) error {
+		entry := app.entries[i]
+		if entry.service == nil {
+			return nil
+		}
+		if hammerCloser, ok := entry.service.(HammerCloser); ok {
+			return hammerCloser.CloseContext(ctx)
+		}
+		return entry.service.Close()
+	})
+}
+
+// closeResources closes every registered resource, in descending dependency-level order,
+// concurrently within each level, after every service has been closed.
+func (app *Application) closeResources(ctx context.Context) error {
+	levels := make([]int, len(app.resources))
+	for i, resource := range app.resources {
+		levels[i] = resource.level
+	}
+	return closeByLevel(ctx, levels, func(i int) error {
+		return app.resources[i].closer.Close()
+	})
+}
+
+// closeByLevel groups indices 0..len(levels)-1 by level and closes them one level at a time,
+// from the highest level (built last, i.e. most dependent) down to zero, so that a dependency is
+// never closed while something built on top of it might still be using it. Indices sharing a
+// level close concurrently, same as before levels existed.
+func closeByLevel(ctx context.Context, levels []int, closeFn func(i int) error) error {
+	if len(levels) == 0 {
+		return nil
+	}
+
+	maxLevel := levels[0]
+	for _, level := range levels {
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+
+	var merged error
+	for level := maxLevel; level >= 0; level-- {
+		var indices []int
+		for i, l := range levels {
+			if l == level {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) == 0 {
+			continue
+		}
+		merged = multierr.Append(merged, closeAllConcurrently(ctx, len(indices), func(j int) error {
+			return closeFn(indices[j])
+		}))
+	}
+	return merged
+}
+
+// closeAllConcurrently calls closeFn(0), closeFn(1), ... closeFn(n-1) concurrently, aggregating
+// every error instead of stopping at the first, so an unrelated slow teardown never hides other
+// failures. It gives up and reports ErrHammerTimeout as soon as ctx is done, even if some
+// closeFn calls (typically the legacy, non-context Close() error fallback) are still blocked, so
+// a single stuck Close can never hang Shutdown forever.
+func closeAllConcurrently(ctx context.Context, n int, closeFn func(i int) error) error {
+	if n == 0 {
+		return nil
+	}
+
+	results := make(chan error, n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			results <- closeFn(i)
+		}()
+	}
+
+	var merged error
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-results:
+			merged = multierr.Append(merged, err)
+		case <-ctx.Done():
+			return multierr.Append(merged, ErrHammerTimeout)
+		}
+	}
+	return merged
+}